@@ -0,0 +1,50 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/dcos/dcos-metrics/producers"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// broadcaster fans a single stream of MetricsMessages out to every
+// currently-subscribed producer, so the collectors only need to publish
+// once regardless of how many producers.MetricsProducer instances are
+// enabled. Subscribing again under the same name (as happens on a SIGHUP
+// restart) replaces that producer's channel.
+type broadcaster struct {
+	mu   sync.Mutex
+	subs map[string]chan producers.MetricsMessage
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{subs: map[string]chan producers.MetricsMessage{}}
+}
+
+// subscribe returns a new buffered channel that will receive every message
+// published after this call, under the given subsystem name.
+func (b *broadcaster) subscribe(name string) chan producers.MetricsMessage {
+	ch := make(chan producers.MetricsMessage, 100)
+
+	b.mu.Lock()
+	b.subs[name] = ch
+	b.mu.Unlock()
+
+	return ch
+}
+
+// publish delivers msg to every subscriber, dropping it for subscribers
+// whose channel is full rather than blocking the collector that called in.
+func (b *broadcaster) publish(msg producers.MetricsMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for name, ch := range b.subs {
+		select {
+		case ch <- msg:
+		default:
+			log.Warnf("%s: dropping metrics message, producer channel is full", name)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -11,6 +12,9 @@ import (
 	"github.com/dcos/dcos-metrics/collector/node"
 	httpHelpers "github.com/dcos/dcos-metrics/http_helpers"
 	httpProducer "github.com/dcos/dcos-metrics/producers/http"
+	kafkaProducer "github.com/dcos/dcos-metrics/producers/kafka"
+	prometheusProducer "github.com/dcos/dcos-metrics/producers/prometheus"
+	statsdProducer "github.com/dcos/dcos-metrics/producers/statsd"
 
 	log "github.com/Sirupsen/logrus"
 	yaml "gopkg.in/yaml.v2"
@@ -41,9 +45,11 @@ type Config struct {
 	ClusterID string
 
 	// Flag configuration
-	DCOSRole   string
-	ConfigPath string
-	LogLevel   string
+	DCOSRole       string
+	ConfigPath     string
+	LogLevel       string
+	CollectorFlags stringSlice
+	ProducerFlags  stringSlice
 }
 
 // CollectorConfig contains configuration options relevant to the "collector"
@@ -51,8 +57,20 @@ type Config struct {
 // et. al to gather metrics and send them to a "producer".
 type CollectorConfig struct {
 	HTTPProfiler bool                             `yaml:"http_profiler"`
-	Node         *node.NodeCollector              `yaml:"node,omitempty"`
-	MesosAgent   *mesos_agent.MesosAgentCollector `yaml:"mesos_agent,omitempty"`
+	// PProfPort is only used when HTTPProfiler is true.
+	PProfPort int `yaml:"pprof_port,omitempty"`
+	// PProfAuth requires the same delegated bouncer authorization as
+	// container metrics before serving /debug/pprof. That authorization is
+	// also required whenever CACertificatePath and IAMConfigPath are both
+	// set, with or without PProfAuth; with PProfAuth set and either of
+	// them missing, pprof is not started at all.
+	PProfAuth bool `yaml:"pprof_auth"`
+	// PProfTLS is the server certificate pprof is served with when
+	// authorization is required; client certificates are verified against
+	// CACertificatePath.
+	PProfTLS   *httpProducer.TLS                `yaml:"pprof_tls,omitempty"`
+	Node       *node.NodeCollector              `yaml:"node,omitempty"`
+	MesosAgent *mesos_agent.MesosAgentCollector `yaml:"mesos_agent,omitempty"`
 }
 
 // ProducersConfig contains references to other structs that provide individual producer configs.
@@ -63,9 +81,10 @@ type CollectorConfig struct {
 // 'producers/kafka/kafka.go'. It is then the responsibility of the individual producers to
 // validate the configuration the user has provided and panic if necessary.
 type ProducersConfig struct {
-	HTTPProducerConfig httpProducer.Config `yaml:"http,omitempty"`
-	//KafkaProducerConfig  kafkaProducer.Config  `yaml:"kafka,omitempty"`
-	//StatsdProducerConfig statsdProducer.Config `yaml:"statsd,omitempty"`
+	HTTPProducerConfig       httpProducer.Config       `yaml:"http,omitempty"`
+	PrometheusProducerConfig prometheusProducer.Config `yaml:"prometheus,omitempty"`
+	KafkaProducerConfig      kafkaProducer.Config      `yaml:"kafka,omitempty"`
+	StatsdProducerConfig     statsdProducer.Config     `yaml:"statsd,omitempty"`
 }
 
 func (c *Config) setFlags(fs *flag.FlagSet) {
@@ -73,6 +92,8 @@ func (c *Config) setFlags(fs *flag.FlagSet) {
 	fs.StringVar(&c.LogLevel, "loglevel", c.LogLevel, "Logging level (default: info). Must be one of: debug, info, warn, error, fatal, panic.")
 	fs.StringVar(&c.DCOSRole, "role", c.DCOSRole, "The DC/OS role this instance runs on.")
 	fs.BoolVar(&c.VersionFlag, "version", c.VersionFlag, "Print version and revsion then exit")
+	fs.Var(&c.CollectorFlags, "collector", "Override a collector's config, e.g. -collector mesos_agent=poll_period=30. Repeatable; CLI values win over the config file.")
+	fs.Var(&c.ProducerFlags, "producer", "Override a producer's config, e.g. -producer prometheus=port=9090,path=/metrics. Repeatable; CLI values win over the config file.")
 }
 
 func (c *Config) loadConfig() error {
@@ -134,6 +155,7 @@ func newConfig() Config {
 	return Config{
 		Collector: CollectorConfig{
 			HTTPProfiler: true,
+			PProfPort:    1024,
 			MesosAgent: &mesos_agent.MesosAgentCollector{
 				PollPeriod: 15,
 				Port:       5051,
@@ -146,6 +168,10 @@ func newConfig() Config {
 			HTTPProducerConfig: httpProducer.Config{
 				Port: 8000,
 			},
+			// PrometheusProducerConfig is left at its zero value, like
+			// the Kafka/StatsD producers: the supervisor only starts it
+			// once the operator sets a port, in the config file or with
+			// -producer.
 		},
 		ConfigPath: "dcos-metrics-config.yaml",
 		LogLevel:   "info",
@@ -168,6 +194,15 @@ func getNewConfig(args []string) (Config, error) {
 		return c, err
 	}
 
+	// -collector/-producer flags are applied after the config file so they
+	// take precedence over it, matching every other flag here.
+	if err := c.applyCollectorOverrides(c.CollectorFlags); err != nil {
+		return c, err
+	}
+	if err := c.applyProducerOverrides(c.ProducerFlags); err != nil {
+		return c, err
+	}
+
 	// Note: .getNodeInfo() is last so we are sure we have all the
 	// configuration we need from flags and config file to make
 	// this run correctly.
@@ -186,3 +221,39 @@ func getNewConfig(args []string) (Config, error) {
 
 	return c, nil
 }
+
+// Redacted returns a copy of c with credentials and key material replaced
+// so it is safe to serve from the HTTP producer's /config endpoint.
+func (c Config) Redacted() Config {
+	redact := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "REDACTED"
+	}
+
+	if c.Producers.PrometheusProducerConfig.BasicAuth != nil {
+		auth := *c.Producers.PrometheusProducerConfig.BasicAuth
+		auth.Password = redact(auth.Password)
+		c.Producers.PrometheusProducerConfig.BasicAuth = &auth
+	}
+
+	if c.Producers.KafkaProducerConfig.SASL != nil {
+		sasl := *c.Producers.KafkaProducerConfig.SASL
+		sasl.Password = redact(sasl.Password)
+		c.Producers.KafkaProducerConfig.SASL = &sasl
+	}
+	if c.Producers.KafkaProducerConfig.TLS != nil {
+		tlsConfig := *c.Producers.KafkaProducerConfig.TLS
+		tlsConfig.KeyPath = redact(tlsConfig.KeyPath)
+		c.Producers.KafkaProducerConfig.TLS = &tlsConfig
+	}
+
+	return c
+}
+
+// RedactedJSON marshals c.Redacted() to JSON, for the HTTP producer's
+// /config endpoint.
+func (c Config) RedactedJSON() ([]byte, error) {
+	return json.Marshal(c.Redacted())
+}
@@ -0,0 +1,28 @@
+package main
+
+import "strings"
+
+// stringSlice is a flag.Value that accumulates values across repeated
+// occurrences of the same flag, e.g. `-producer prometheus=port=9090
+// -producer statsd=port=8125`. A single occurrence may also carry several
+// entries separated by semicolons, e.g. `-producer
+// "prometheus=port=9090;statsd=port=8125"`; commas are left alone so each
+// entry can itself be a "name=k=v,k=v" override.
+type stringSlice []string
+
+// String implements flag.Value.
+func (s *stringSlice) String() string {
+	return strings.Join(*s, ";")
+}
+
+// Set implements flag.Value, appending one or more semicolon-separated
+// entries from value.
+func (s *stringSlice) Set(value string) error {
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			*s = append(*s, entry)
+		}
+	}
+	return nil
+}
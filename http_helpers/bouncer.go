@@ -0,0 +1,93 @@
+package http_helpers
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// AccessReview mirrors a Kubernetes SubjectAccessReview, adapted for the
+// DC/OS bouncer: does Principal have permission to perform Action on RID?
+type AccessReview struct {
+	Principal string `json:"uid"`
+	RID       string `json:"rid"`
+	Action    string `json:"action"`
+}
+
+// AccessReviewResult is the bouncer's answer to an AccessReview.
+type AccessReviewResult struct {
+	Allowed bool `json:"allowed"`
+}
+
+// BouncerAuthorizer delegates authorization decisions to the DC/OS
+// bouncer: a client certificate's CN/OU is mapped to a principal, and the
+// bouncer is asked whether that principal may perform a given action on a
+// given DC/OS resource ID (RID), e.g. "dcos:adminrouter:ops:metrics".
+type BouncerAuthorizer struct {
+	// BaseURL is the bouncer's API root, e.g. "https://leader.mesos/acs/api/v1".
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewBouncerAuthorizer builds a BouncerAuthorizer with its own client,
+// built by NewMetricsClient just as the collector's client for Mesos is.
+func NewBouncerAuthorizer(baseURL, caCertificatePath, iamConfigPath string) (*BouncerAuthorizer, error) {
+	client, err := NewMetricsClient(caCertificatePath, iamConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return &BouncerAuthorizer{BaseURL: baseURL, Client: client}, nil
+}
+
+// Authorize reports whether cert's principal may perform action on rid.
+// A principal with no usable CN or OU is always denied.
+func (b *BouncerAuthorizer) Authorize(cert *x509.Certificate, rid, action string) (bool, error) {
+	principal := PrincipalFromCertificate(cert)
+	if principal == "" {
+		return false, nil
+	}
+
+	review := AccessReview{Principal: principal, RID: rid, Action: action}
+	body, err := json.Marshal(review)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, b.BaseURL+"/permissions/review", bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("bouncer returned status %d reviewing %s for %s", resp.StatusCode, rid, principal)
+	}
+
+	var result AccessReviewResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, err
+	}
+
+	return result.Allowed, nil
+}
+
+// PrincipalFromCertificate maps a client certificate to a DC/OS principal,
+// preferring its Common Name and falling back to the first Organizational
+// Unit.
+func PrincipalFromCertificate(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.Subject.OrganizationalUnit) > 0 {
+		return cert.Subject.OrganizationalUnit[0]
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	httpHelpers "github.com/dcos/dcos-metrics/http_helpers"
+	httpProducer "github.com/dcos/dcos-metrics/producers/http"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+func main() {
+	args := os.Args[1:]
+
+	cfg, err := getNewConfig(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if cfg.VersionFlag {
+		fmt.Printf("Version: %s, Revision: %s\n", VERSION, REVISION)
+		return
+	}
+
+	level, err := log.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.SetLevel(level)
+
+	manager := newConfigManager(cfg, args)
+	httpProducer.SetConfigProvider(manager)
+
+	authorizer, err := newAuthorizer(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	broadcast := newBroadcaster()
+	sup := newSupervisor(manager, broadcast.subscribe, broadcast.publish)
+	sup.SetAuthorizer(authorizer)
+	sup.SetCACertificatePath(cfg.CACertificatePath)
+
+	if cfg.Collector.HTTPProfiler {
+		go func() {
+			// An operator with mTLS/IAM configured gets pprof auth whether
+			// or not they remembered to also set pprof_auth; an operator
+			// asking for pprof_auth without CA/IAM configured is refused
+			// below rather than served unauthenticated.
+			authRequired := cfg.Collector.PProfAuth || authorizer != nil
+			if authRequired && authorizer == nil {
+				log.Errorf("pprof_auth is set but no authorizer could be built (ca_certificate_path/iam_config_path missing); refusing to start pprof")
+				return
+			}
+
+			// Loopback-only unless auth is actually enforced, so pprof
+			// isn't exposed unauthenticated on every interface by default.
+			host := "127.0.0.1"
+			if authRequired {
+				host = ""
+			}
+			addr := fmt.Sprintf("%s:%d", host, cfg.Collector.PProfPort)
+			handler := pprofHandler(authorizer)
+
+			if authRequired {
+				if cfg.Collector.PProfTLS == nil {
+					log.Errorf("pprof_auth is set but pprof_tls is not configured; refusing to start pprof")
+					return
+				}
+				tlsConfig, err := httpProducer.ListenerTLSConfig(cfg.CACertificatePath)
+				if err != nil {
+					log.Errorf("could not configure pprof TLS: %s", err)
+					return
+				}
+				server := &http.Server{Addr: addr, Handler: handler, TLSConfig: tlsConfig}
+				log.Infof("Starting pprof endpoint on %s (mTLS)", addr)
+				if err := server.ListenAndServeTLS(cfg.Collector.PProfTLS.CertificatePath, cfg.Collector.PProfTLS.KeyPath); err != nil {
+					log.Errorf("pprof listener exited: %s", err)
+				}
+				return
+			}
+
+			log.Infof("Starting pprof endpoint on %s (loopback only, unauthenticated)", addr)
+			if err := http.ListenAndServe(addr, handler); err != nil {
+				log.Errorf("pprof listener exited: %s", err)
+			}
+		}()
+	}
+
+	// Start every collector and configurable producer once at boot, as if
+	// everything had just "changed" from nothing.
+	sup.reconcile(reloadDiff{
+		nodeCollector:       true,
+		mesosAgentCollector: true,
+		httpProducer:        true,
+		prometheusProducer:  true,
+		kafkaProducer:       true,
+		statsdProducer:      true,
+	})
+
+	watchSIGHUP(func() (reloadDiff, error) {
+		diff, err := manager.Reload()
+		if err != nil {
+			return diff, err
+		}
+		sup.reconcile(diff)
+		return diff, nil
+	})
+
+	select {}
+}
+
+// newAuthorizer builds a delegated bouncer authorizer when the operator has
+// configured mTLS (CACertificatePath) and IAM (IAMConfigPath); otherwise it
+// returns nil, and every producer falls back to today's unauthenticated
+// behavior.
+func newAuthorizer(cfg Config) (*httpHelpers.BouncerAuthorizer, error) {
+	if cfg.CACertificatePath == "" || cfg.IAMConfigPath == "" {
+		return nil, nil
+	}
+
+	const bouncerBaseURL = "https://leader.mesos/acs/api/v1"
+	return httpHelpers.NewBouncerAuthorizer(bouncerBaseURL, cfg.CACertificatePath, cfg.IAMConfigPath)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dcos/dcos-metrics/collector/mesos_agent"
+	"github.com/dcos/dcos-metrics/collector/node"
+)
+
+// pluginOverride is a single parsed "-collector"/"-producer" flag entry:
+// values to apply, keyed by yaml tag name, on top of that plugin's section
+// of the config file.
+type pluginOverride struct {
+	name   string
+	values map[string]string
+}
+
+// parsePluginOverride parses a single "name=key=value[,key=value...]" entry.
+func parsePluginOverride(entry string) (pluginOverride, error) {
+	nameAndRest := strings.SplitN(entry, "=", 2)
+	if len(nameAndRest) != 2 {
+		return pluginOverride{}, fmt.Errorf("invalid override %q: expected name=key=value[,key=value...]", entry)
+	}
+
+	values := map[string]string{}
+	for _, pair := range strings.Split(nameAndRest[1], ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return pluginOverride{}, fmt.Errorf("invalid key=value pair %q in override for %q", pair, nameAndRest[0])
+		}
+		values[kv[0]] = kv[1]
+	}
+
+	return pluginOverride{name: nameAndRest[0], values: values}, nil
+}
+
+// applyProducerOverrides validates each entry against the registered
+// producer names and applies its values on top of c.Producers, failing
+// fast (and listing the registered names) on an unrecognized producer.
+// The registered names are the keys of targets below; each points at a
+// field of ProducersConfig, so a new producer needs both a field there and
+// an entry here.
+func (c *Config) applyProducerOverrides(entries []string) error {
+	targets := map[string]interface{}{
+		"http":       &c.Producers.HTTPProducerConfig,
+		"prometheus": &c.Producers.PrometheusProducerConfig,
+		"kafka":      &c.Producers.KafkaProducerConfig,
+		"statsd":     &c.Producers.StatsdProducerConfig,
+	}
+	return applyOverrides("producer", entries, targets)
+}
+
+// applyCollectorOverrides validates each entry against the registered
+// collector names and applies its values on top of c.Collector, failing
+// fast (and listing the registered names) on an unrecognized collector.
+func (c *Config) applyCollectorOverrides(entries []string) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if c.Collector.Node == nil {
+		c.Collector.Node = &node.NodeCollector{}
+	}
+	if c.Collector.MesosAgent == nil {
+		c.Collector.MesosAgent = &mesos_agent.MesosAgentCollector{}
+	}
+
+	targets := map[string]interface{}{
+		"node":        c.Collector.Node,
+		"mesos_agent": c.Collector.MesosAgent,
+	}
+	return applyOverrides("collector", entries, targets)
+}
+
+// applyOverrides parses each raw entry and reflectively sets the matching
+// yaml-tagged fields on targets[name].
+func applyOverrides(kind string, entries []string, targets map[string]interface{}) error {
+	for _, raw := range entries {
+		override, err := parsePluginOverride(raw)
+		if err != nil {
+			return err
+		}
+
+		target, ok := targets[override.name]
+		if !ok {
+			return fmt.Errorf("unknown %s %q, registered %ss are: %s", kind, override.name, kind, registeredNames(targets))
+		}
+
+		if err := setFields(target, override.values); err != nil {
+			return fmt.Errorf("could not apply -%s %s: %s", kind, override.name, err)
+		}
+	}
+	return nil
+}
+
+func registeredNames(targets map[string]interface{}) string {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// setFields sets each key in values on the yaml-tagged field of the same
+// name in target, which must be a pointer to a struct. Slice-of-string
+// fields accept "|"-separated values, since "," already separates
+// key=value pairs on the command line.
+func setFields(target interface{}, values map[string]string) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("internal error: override target must be a pointer to a struct")
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	fieldByTag := map[string]reflect.Value{}
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag != "" && tag != "-" {
+			fieldByTag[tag] = v.Field(i)
+		}
+	}
+
+	for key, value := range values {
+		field, ok := fieldByTag[key]
+		if !ok {
+			return fmt.Errorf("unknown option %q", key)
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("option %q: %s", key, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", field.Type())
+		}
+		field.Set(reflect.ValueOf(strings.Split(value, "|")))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Kind())
+	}
+	return nil
+}
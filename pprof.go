@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	httpHelpers "github.com/dcos/dcos-metrics/http_helpers"
+	httpProducer "github.com/dcos/dcos-metrics/producers/http"
+)
+
+// pprofHandler returns the standard net/http/pprof endpoints, gated behind
+// delegated bouncer authorization when auth is required. Callers must not
+// request auth (pass a non-nil authorizer) unless one was actually built;
+// main refuses to start the pprof listener when auth is required but no
+// authorizer exists, rather than serving pprof unauthenticated.
+func pprofHandler(authorizer *httpHelpers.BouncerAuthorizer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	if authorizer == nil {
+		return mux
+	}
+	return httpProducer.AuthzMiddleware(authorizer, mux)
+}
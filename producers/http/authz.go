@@ -0,0 +1,93 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	httpHelpers "github.com/dcos/dcos-metrics/http_helpers"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// MetricsRID is the DC/OS resource ID that governs read access to
+// container metrics and profiling endpoints exposed by this producer.
+const MetricsRID = "dcos:adminrouter:ops:metrics"
+
+// ListenerTLSConfig builds the TLS configuration for the producer's
+// listener when mTLS is enabled: client certificates are verified against
+// caCertificatePath and required on every connection.
+func ListenerTLSConfig(caCertificatePath string) (*tls.Config, error) {
+	caCert, err := ioutil.ReadFile(caCertificatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("could not parse CA certificate %q", caCertificatePath)
+	}
+
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+	}, nil
+}
+
+// AuthzMiddleware wraps next so that requests must present a client
+// certificate whose mapped principal is authorized, per authorizer, to
+// read MetricsRID. When authorizer is nil (mTLS not configured), requests
+// pass through unauthenticated, preserving today's behavior.
+func AuthzMiddleware(authorizer *httpHelpers.BouncerAuthorizer, next http.Handler) http.Handler {
+	if authorizer == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+
+		allowed, err := authorizer.Authorize(r.TLS.PeerCertificates[0], MetricsRID, "read")
+		if err != nil {
+			log.Errorf("authorization check failed: %s", err)
+			http.Error(w, "authorization check failed", http.StatusServiceUnavailable)
+			return
+		}
+		if !allowed {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LoopbackExempt wraps next so that unauthenticated access is only
+// permitted when the request arrived on loopback; every other request is
+// still subject to AuthzMiddleware. This lets a Prometheus-style /metrics
+// endpoint stay open to `curl localhost` without exposing it externally.
+func LoopbackExempt(authorizer *httpHelpers.BouncerAuthorizer, next http.Handler) http.Handler {
+	authorized := AuthzMiddleware(authorizer, next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isLoopback(r.RemoteAddr) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authorized.ServeHTTP(w, r)
+	})
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
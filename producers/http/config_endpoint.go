@@ -0,0 +1,39 @@
+package http
+
+import "net/http"
+
+// ConfigProvider supplies the currently active, secret-redacted
+// configuration as JSON. It is implemented by the top-level Config type in
+// package main; the indirection here avoids an import cycle back into it.
+type ConfigProvider interface {
+	RedactedJSON() ([]byte, error)
+}
+
+var configProvider ConfigProvider
+
+// SetConfigProvider registers the source Run queries when serving
+// "/config". It is called once, before Run starts listening.
+func SetConfigProvider(provider ConfigProvider) {
+	configProvider = provider
+}
+
+// ConfigHandler serves the registered ConfigProvider's redacted
+// configuration as JSON, so operators can confirm that a SIGHUP-triggered
+// reload took effect. Run always registers it at "/config"; it answers
+// 404 Not Found while no ConfigProvider has been set.
+func ConfigHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if configProvider == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := configProvider.RedactedJSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
@@ -0,0 +1,150 @@
+// Package http implements a producers.MetricsProducer that serves the most
+// recently observed MetricsMessage per container for polling consumers
+// (e.g. the Mesos UI, adminrouter), alongside the "/config" endpoint
+// registered via SetConfigProvider.
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	httpHelpers "github.com/dcos/dcos-metrics/http_helpers"
+	"github.com/dcos/dcos-metrics/producers"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// TLS holds the server certificate/key used to serve container metrics over
+// HTTPS; required alongside the top-level CACertificatePath to enable mTLS.
+type TLS struct {
+	CertificatePath string `yaml:"certificate_path"`
+	KeyPath         string `yaml:"key_path"`
+}
+
+// Config contains configuration options for the HTTP producer.
+type Config struct {
+	Port int  `yaml:"port"`
+	TLS  *TLS `yaml:"tls,omitempty"`
+}
+
+// producer implements producers.MetricsProducer, serving the latest
+// MetricsMessage per container_id at "/v0/containers/<id>".
+type producer struct {
+	config Config
+	server *http.Server
+
+	mu       sync.Mutex
+	messages map[string]producers.MetricsMessage
+
+	authorizer   *httpHelpers.BouncerAuthorizer
+	clientCAPath string
+}
+
+// SetAuthorizer registers the delegated authorizer used to gate container
+// metrics. It must be called, if at all, before Run.
+func (p *producer) SetAuthorizer(authorizer *httpHelpers.BouncerAuthorizer) {
+	p.authorizer = authorizer
+}
+
+// SetClientCAPath registers the CA used to verify client certificates when
+// serving over mTLS. It must be called, if at all, before Run.
+func (p *producer) SetClientCAPath(path string) {
+	p.clientCAPath = path
+}
+
+// New constructs an HTTP producer from cfg. The listener is not opened
+// until Run is called.
+func New(cfg Config) (producers.MetricsProducer, error) {
+	if cfg.Port == 0 {
+		return nil, fmt.Errorf("http producer requires a port")
+	}
+
+	return &producer{
+		config:   cfg,
+		messages: map[string]producers.MetricsMessage{},
+	}, nil
+}
+
+// Run consumes MetricsMessages off in and updates the producer's latest-seen
+// table until the context is cancelled. It blocks on ListenAndServe(TLS) for
+// the lifetime of the producer.
+func (p *producer) Run(ctx context.Context, in chan producers.MetricsMessage) error {
+	go func() {
+		for {
+			select {
+			case msg, ok := <-in:
+				if !ok {
+					return
+				}
+				p.observe(msg)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/v0/containers/", p.containersHandler())
+	mux.Handle("/config", ConfigHandler())
+
+	var handler http.Handler = mux
+	if p.authorizer != nil {
+		handler = AuthzMiddleware(p.authorizer, handler)
+	}
+
+	p.server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", p.config.Port),
+		Handler: handler,
+	}
+
+	// Close the listener when the context is cancelled, so a restart can
+	// rebind the port.
+	go func() {
+		<-ctx.Done()
+		p.server.Close()
+	}()
+
+	log.Infof("Starting HTTP producer on port %d", p.config.Port)
+
+	if p.clientCAPath != "" && p.config.TLS != nil {
+		tlsConfig, err := ListenerTLSConfig(p.clientCAPath)
+		if err != nil {
+			return err
+		}
+		p.server.TLSConfig = tlsConfig
+		return p.server.ListenAndServeTLS(p.config.TLS.CertificatePath, p.config.TLS.KeyPath)
+	}
+	return p.server.ListenAndServe()
+}
+
+func (p *producer) containersHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		containerID := r.URL.Path[len("/v0/containers/"):]
+
+		p.mu.Lock()
+		msg, ok := p.messages[containerID]
+		p.mu.Unlock()
+
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(msg)
+	})
+}
+
+// observe records the most recently seen MetricsMessage for each container.
+func (p *producer) observe(msg producers.MetricsMessage) {
+	if msg.Dimensions.ContainerID == "" {
+		return
+	}
+
+	p.mu.Lock()
+	p.messages[msg.Dimensions.ContainerID] = msg
+	p.mu.Unlock()
+}
@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"github.com/dcos/dcos-metrics/producers"
+	"github.com/linkedin/goavro/v2"
+)
+
+// metricsMessageSchema is the Avro schema for producers.MetricsMessage.
+// It is not checked against that struct: encode builds the native value by
+// hand, so a struct field missing here is not encoded, and a field listed
+// here without a default but missing from encode makes every encode fail.
+const metricsMessageSchema = `{
+	"type": "record",
+	"name": "MetricsMessage",
+	"namespace": "dcos.metrics",
+	"fields": [
+		{"name": "name", "type": "string"},
+		{"name": "timestamp", "type": "long"},
+		{"name": "datapoints", "type": {"type": "array", "items": {
+			"type": "record",
+			"name": "Datapoint",
+			"fields": [
+				{"name": "name", "type": "string"},
+				{"name": "unit", "type": ["null", "string"], "default": null},
+				{"name": "value", "type": "double"},
+				{"name": "timestamp", "type": "string"},
+				{"name": "tags", "type": {"type": "map", "values": "string"}, "default": {}}
+			]
+		}}},
+		{"name": "dimensions", "type": {
+			"type": "record",
+			"name": "Dimensions",
+			"fields": [
+				{"name": "mesos_id", "type": "string"},
+				{"name": "cluster_id", "type": "string"},
+				{"name": "container_id", "type": ["null", "string"], "default": null},
+				{"name": "executor_id", "type": ["null", "string"], "default": null},
+				{"name": "framework_id", "type": ["null", "string"], "default": null},
+				{"name": "framework_name", "type": ["null", "string"], "default": null}
+			]
+		}}
+	]
+}`
+
+// avroCodec holds metricsMessageSchema, compiled once per producer in New.
+type avroCodec struct {
+	codec *goavro.Codec
+}
+
+func newAvroCodec() (*avroCodec, error) {
+	codec, err := goavro.NewCodec(metricsMessageSchema)
+	if err != nil {
+		return nil, err
+	}
+	return &avroCodec{codec: codec}, nil
+}
+
+// encode converts msg into its native Avro representation and returns the
+// binary-encoded payload, ready to be framed with the Confluent wire format.
+func (a *avroCodec) encode(msg producers.MetricsMessage) ([]byte, error) {
+	native := map[string]interface{}{
+		"name":      msg.Name,
+		"timestamp": msg.Timestamp,
+		"datapoints": datapointsToNative(msg.Datapoints),
+		"dimensions": map[string]interface{}{
+			"mesos_id":       msg.Dimensions.MesosID,
+			"cluster_id":     msg.Dimensions.ClusterID,
+			"container_id":   avroNullableString(msg.Dimensions.ContainerID),
+			"executor_id":    avroNullableString(msg.Dimensions.ExecutorID),
+			"framework_id":   avroNullableString(msg.Dimensions.FrameworkID),
+			"framework_name": avroNullableString(msg.Dimensions.FrameworkName),
+		},
+	}
+
+	return a.codec.BinaryFromNative(nil, native)
+}
+
+func datapointsToNative(datapoints []producers.Datapoint) []interface{} {
+	out := make([]interface{}, 0, len(datapoints))
+	for _, dp := range datapoints {
+		tags := map[string]interface{}{}
+		for k, v := range dp.Tags {
+			tags[k] = v
+		}
+		out = append(out, map[string]interface{}{
+			"name":      dp.Name,
+			"unit":      avroNullableString(dp.Unit),
+			"value":     toFloat64(dp.Value),
+			"timestamp": dp.Timestamp,
+			"tags":      tags,
+		})
+	}
+	return out
+}
+
+// avroNullableString wraps s for an Avro ["null", "string"] union, per
+// goavro's convention of keying the non-null branch by its type name.
+func avroNullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return map[string]interface{}{"string": s}
+}
+
+func toFloat64(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	default:
+		return 0
+	}
+}
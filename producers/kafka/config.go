@@ -0,0 +1,51 @@
+package kafka
+
+// Compression identifies the Sarama compression codec to use for published
+// messages.
+type Compression string
+
+// Supported Compression values.
+const (
+	CompressionNone   Compression = "none"
+	CompressionGzip   Compression = "gzip"
+	CompressionSnappy Compression = "snappy"
+	CompressionLZ4    Compression = "lz4"
+	CompressionZstd   Compression = "zstd"
+)
+
+// TLS holds the client certificate configuration used to connect to brokers
+// over TLS.
+type TLS struct {
+	CACertificatePath  string `yaml:"ca_certificate_path"`
+	CertificatePath    string `yaml:"certificate_path,omitempty"`
+	KeyPath            string `yaml:"key_path,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// SASL holds SASL/PLAIN credentials used to authenticate to brokers.
+// SCRAM is not supported: Sarama requires a SCRAMClientGeneratorFunc to
+// actually perform the handshake, which this producer does not provide.
+type SASL struct {
+	Enabled   bool   `yaml:"enabled"`
+	Mechanism string `yaml:"mechanism"` // must be PLAIN
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+}
+
+// Config contains configuration options for the Kafka producer.
+type Config struct {
+	Brokers []string `yaml:"brokers"`
+	// Topic may reference a MetricsMessage's ClusterID, FrameworkName,
+	// FrameworkID and MesosID dimensions using Go template syntax,
+	// e.g. "metrics-{{.ClusterID}}-{{.FrameworkName}}".
+	Topic       string      `yaml:"topic"`
+	FlushPeriod int         `yaml:"flush_period"` // seconds
+	Compression Compression `yaml:"compression"`
+	TLS         *TLS        `yaml:"tls,omitempty"`
+	SASL        *SASL       `yaml:"sasl,omitempty"`
+
+	// SchemaRegistryURL points at a Confluent-compatible schema registry.
+	// When set, messages are Avro-encoded and framed with the Confluent
+	// wire format; otherwise messages fall back to plain JSON.
+	SchemaRegistryURL string `yaml:"schema_registry_url,omitempty"`
+}
@@ -0,0 +1,10 @@
+package kafka
+
+import "time"
+
+// secondsToDuration converts a config value expressed in whole seconds
+// (matching the rest of this project's *Period fields) into a
+// time.Duration.
+func secondsToDuration(seconds int) time.Duration {
+	return time.Duration(seconds) * time.Second
+}
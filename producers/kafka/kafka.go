@@ -0,0 +1,239 @@
+// Package kafka implements a producers.MetricsProducer that publishes
+// MetricsMessages to a Kafka cluster, optionally Avro-encoding them against
+// a Confluent-compatible schema registry. Messages fall back to plain JSON
+// when no schema registry is configured.
+//
+// Requires a vendored github.com/Shopify/sarama new enough to provide
+// CompressionZSTD and cfg.Net.SASL.Mechanism; both are used below.
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/dcos/dcos-metrics/producers"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/Shopify/sarama"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// producer implements producers.MetricsProducer, publishing to Kafka.
+type producer struct {
+	config  Config
+	client  sarama.AsyncProducer
+	topics  *topicResolver
+	avro    *avroCodec
+	schemas *schemaRegistryClient
+	subject string
+
+	metricRegistry metrics.Registry
+}
+
+// New constructs a Kafka producer from cfg. The Sarama client is not
+// connected until Run is called.
+func New(cfg Config) (producers.MetricsProducer, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka producer requires at least one broker")
+	}
+	if cfg.Topic == "" {
+		return nil, fmt.Errorf("kafka producer requires a topic")
+	}
+
+	topics, err := newTopicResolver(cfg.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid topic template %q: %s", cfg.Topic, err)
+	}
+
+	p := &producer{
+		config:         cfg,
+		topics:         topics,
+		metricRegistry: metrics.NewRegistry(),
+	}
+
+	if cfg.SchemaRegistryURL != "" {
+		p.avro, err = newAvroCodec()
+		if err != nil {
+			return nil, fmt.Errorf("could not compile avro schema: %s", err)
+		}
+		p.schemas = newSchemaRegistryClient(cfg.SchemaRegistryURL, http.DefaultClient)
+		p.subject = cfg.Topic + "-value"
+	}
+
+	return p, nil
+}
+
+// Run connects to the configured brokers and publishes MetricsMessages off
+// in until the context is cancelled.
+func (p *producer) Run(ctx context.Context, in chan producers.MetricsMessage) error {
+	saramaConfig, err := p.saramaConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := sarama.NewAsyncProducer(p.config.Brokers, saramaConfig)
+	if err != nil {
+		return err
+	}
+	p.client = client
+	defer client.Close()
+
+	go func() {
+		for err := range client.Errors() {
+			log.Errorf("kafka producer: %s", err)
+		}
+	}()
+
+	for {
+		select {
+		case msg, ok := <-in:
+			if !ok {
+				return nil
+			}
+			if err := p.publish(msg); err != nil {
+				log.Errorf("kafka producer: could not publish message: %s", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// publish serializes msg and enqueues it on the Sarama async producer,
+// keyed by container_id so all of a task's metrics land on one partition.
+func (p *producer) publish(msg producers.MetricsMessage) error {
+	topic, err := p.topics.resolve(msg)
+	if err != nil {
+		return err
+	}
+
+	payload, err := p.serialize(msg)
+	if err != nil {
+		return err
+	}
+
+	p.client.Input() <- &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(msg.Dimensions.ContainerID),
+		Value: sarama.ByteEncoder(payload),
+	}
+	return nil
+}
+
+// serialize Avro-encodes msg and frames it with the Confluent wire format
+// when a schema registry is configured; otherwise it falls back to JSON.
+func (p *producer) serialize(msg producers.MetricsMessage) ([]byte, error) {
+	if p.avro == nil {
+		return json.Marshal(msg)
+	}
+
+	schemaID, err := p.schemas.schemaIDFor(p.subject, metricsMessageSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	avroPayload, err := p.avro.encode(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeConfluentWireFormat(schemaID, avroPayload), nil
+}
+
+// saramaConfig translates Config into a sarama.Config, wiring in the
+// container_id partitioner, TLS/SASL settings, and p.metricRegistry.
+func (p *producer) saramaConfig() (*sarama.Config, error) {
+	cfg := sarama.NewConfig()
+	cfg.Producer.Return.Errors = true
+	// Hashes ProducerMessage.Key (container_id), so a task's datapoints
+	// always land on the same partition.
+	cfg.Producer.Partitioner = sarama.NewHashPartitioner
+
+	if p.config.FlushPeriod > 0 {
+		cfg.Producer.Flush.Frequency = secondsToDuration(p.config.FlushPeriod)
+	}
+
+	codec, err := compressionCodec(p.config.Compression)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Producer.Compression = codec
+
+	if p.config.TLS != nil {
+		tlsConfig, err := newTLSConfig(p.config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Net.TLS.Enable = true
+		cfg.Net.TLS.Config = tlsConfig
+	}
+
+	if p.config.SASL != nil && p.config.SASL.Enabled {
+		mechanism := p.config.SASL.Mechanism
+		if mechanism == "" {
+			mechanism = string(sarama.SASLTypePlaintext)
+		}
+		if sarama.SASLMechanism(mechanism) != sarama.SASLTypePlaintext {
+			return nil, fmt.Errorf("kafka producer: unsupported sasl mechanism %q, only PLAIN is supported", mechanism)
+		}
+
+		cfg.Net.SASL.Enable = true
+		cfg.Net.SASL.User = p.config.SASL.Username
+		cfg.Net.SASL.Password = p.config.SASL.Password
+		cfg.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	}
+
+	cfg.MetricRegistry = p.metricRegistry
+
+	return cfg, nil
+}
+
+func compressionCodec(c Compression) (sarama.CompressionCodec, error) {
+	switch c {
+	case "", CompressionNone:
+		return sarama.CompressionNone, nil
+	case CompressionGzip:
+		return sarama.CompressionGZIP, nil
+	case CompressionSnappy:
+		return sarama.CompressionSnappy, nil
+	case CompressionLZ4:
+		return sarama.CompressionLZ4, nil
+	case CompressionZstd:
+		return sarama.CompressionZSTD, nil
+	default:
+		return sarama.CompressionNone, fmt.Errorf("unknown compression %q", c)
+	}
+}
+
+func newTLSConfig(cfg *TLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CACertificatePath != "" {
+		caCert, err := ioutil.ReadFile(cfg.CACertificatePath)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("could not parse CA certificate %q", cfg.CACertificatePath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertificatePath != "" && cfg.KeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertificatePath, cfg.KeyPath)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
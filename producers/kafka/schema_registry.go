@@ -0,0 +1,113 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// confluentMagicByte is prepended to every Avro-encoded message to mark it
+// as using the Confluent wire format.
+const confluentMagicByte = byte(0x00)
+
+// schemaRegistryContentType is the media type the Confluent schema registry
+// expects for schema registration requests.
+const schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+
+// schemaRegistryClient registers/looks up the MetricsMessage Avro schema
+// against a Confluent-compatible schema registry and caches the resulting
+// subject -> schema ID mapping, since the schema ID is stable for the
+// lifetime of a given schema version.
+type schemaRegistryClient struct {
+	baseURL string
+	http    *http.Client
+
+	mu  sync.RWMutex
+	ids map[string]int32 // subject -> schema ID
+}
+
+func newSchemaRegistryClient(baseURL string, httpClient *http.Client) *schemaRegistryClient {
+	return &schemaRegistryClient{
+		baseURL: baseURL,
+		http:    httpClient,
+		ids:     map[string]int32{},
+	}
+}
+
+type registerSchemaRequest struct {
+	Schema string `json:"schema"`
+}
+
+type registerSchemaResponse struct {
+	ID int32 `json:"id"`
+}
+
+// schemaIDFor returns the registry's ID for schema under subject,
+// registering it if this is the first time the client has seen that
+// subject.
+func (c *schemaRegistryClient) schemaIDFor(subject, schema string) (int32, error) {
+	c.mu.RLock()
+	id, ok := c.ids[subject]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	id, err := c.registerSchema(subject, schema)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.ids[subject] = id
+	c.mu.Unlock()
+
+	return id, nil
+}
+
+func (c *schemaRegistryClient) registerSchema(subject, schema string) (int32, error) {
+	body, err := json.Marshal(registerSchemaRequest{Schema: schema})
+	if err != nil {
+		return 0, err
+	}
+
+	url := fmt.Sprintf("%s/subjects/%s/versions", c.baseURL, subject)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", schemaRegistryContentType)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("schema registry returned status %d registering subject %s", resp.StatusCode, subject)
+	}
+
+	var registered registerSchemaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&registered); err != nil {
+		return 0, err
+	}
+
+	return registered.ID, nil
+}
+
+// encodeConfluentWireFormat prefixes an Avro-encoded payload with the
+// Confluent wire format header: magic byte 0x00 followed by the 4-byte
+// big-endian schema ID.
+func encodeConfluentWireFormat(schemaID int32, avroPayload []byte) []byte {
+	out := make([]byte, 0, 5+len(avroPayload))
+	out = append(out, confluentMagicByte)
+	idBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(idBytes, uint32(schemaID))
+	out = append(out, idBytes...)
+	out = append(out, avroPayload...)
+	return out
+}
@@ -0,0 +1,45 @@
+package kafka
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/dcos/dcos-metrics/producers"
+)
+
+// topicResolver renders Config.Topic as a Go template against a
+// MetricsMessage's dimensions, so operators can route messages to
+// per-cluster or per-framework topics.
+type topicResolver struct {
+	tmpl *template.Template
+}
+
+// topicVars is the set of fields available to the Config.Topic template.
+type topicVars struct {
+	ClusterID     string
+	FrameworkName string
+	FrameworkID   string
+	MesosID       string
+}
+
+func newTopicResolver(topic string) (*topicResolver, error) {
+	tmpl, err := template.New("topic").Parse(topic)
+	if err != nil {
+		return nil, err
+	}
+	return &topicResolver{tmpl: tmpl}, nil
+}
+
+func (r *topicResolver) resolve(msg producers.MetricsMessage) (string, error) {
+	var buf bytes.Buffer
+	vars := topicVars{
+		ClusterID:     msg.Dimensions.ClusterID,
+		FrameworkName: msg.Dimensions.FrameworkName,
+		FrameworkID:   msg.Dimensions.FrameworkID,
+		MesosID:       msg.Dimensions.MesosID,
+	}
+	if err := r.tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
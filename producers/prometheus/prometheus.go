@@ -0,0 +1,279 @@
+// Package prometheus implements a producers.MetricsProducer that exposes
+// DC/OS container and node metrics for scraping by Prometheus, rather than
+// pushing them to a remote collector. It translates each producers.MetricsMessage
+// into one or more Prometheus samples, labelled with the dimensions DC/OS
+// metrics consumers already expect (mesos_id, cluster_id, framework_name,
+// executor_id, container_id), and serves them on Config.Path via Config.Port.
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	httpHelpers "github.com/dcos/dcos-metrics/http_helpers"
+	"github.com/dcos/dcos-metrics/producers"
+	httpProducer "github.com/dcos/dcos-metrics/producers/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// counterMetrics lists the datapoint names that should be exposed as
+// Prometheus counters rather than gauges. Everything else is treated as a
+// gauge, matching how the HTTP producer reports instantaneous values.
+var counterMetrics = map[string]bool{
+	"cpus_user_time_secs":   true,
+	"cpus_system_time_secs": true,
+	"net_rx_bytes":          true,
+	"net_tx_bytes":          true,
+	"net_rx_packets":        true,
+	"net_tx_packets":        true,
+	"net_rx_errors":         true,
+	"net_tx_errors":         true,
+	"net_rx_dropped":        true,
+	"net_tx_dropped":        true,
+}
+
+// labelNames are the dimension labels attached to every Prometheus series
+// produced from a MetricsMessage.
+var labelNames = []string{"mesos_id", "cluster_id", "framework_name", "executor_id", "container_id"}
+
+// BasicAuth holds the username/password required of scrapers when set.
+type BasicAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// TLS holds the server certificate/key used to serve /metrics over HTTPS.
+type TLS struct {
+	CertificatePath string `yaml:"certificate_path"`
+	KeyPath         string `yaml:"key_path"`
+}
+
+// Config contains configuration options for the Prometheus producer.
+type Config struct {
+	Port      int        `yaml:"port"`
+	Path      string     `yaml:"path"`
+	BasicAuth *BasicAuth `yaml:"basic_auth,omitempty"`
+	TLS       *TLS       `yaml:"tls,omitempty"`
+}
+
+// sample is the most recently observed value for a single labelled series.
+type sample struct {
+	valueType prometheus.ValueType
+	value     float64
+	labels    []string
+}
+
+// producer implements producers.MetricsProducer for Prometheus scraping. It
+// is itself a prometheus.Collector: rather than mutating Gauge/Counter
+// instances (which cannot be set to an arbitrary absolute value), it keeps
+// the latest sample per series and emits them as const metrics on each scrape.
+type producer struct {
+	config   Config
+	server   *http.Server
+	registry *prometheus.Registry
+
+	mu      sync.Mutex
+	descs   map[string]*prometheus.Desc
+	samples map[string]map[string]sample // metric name -> series key -> sample
+
+	authorizer   *httpHelpers.BouncerAuthorizer
+	clientCAPath string
+}
+
+// SetAuthorizer registers the delegated authorizer used to gate /metrics
+// for callers outside loopback. It must be called, if at all, before Run.
+func (p *producer) SetAuthorizer(authorizer *httpHelpers.BouncerAuthorizer) {
+	p.authorizer = authorizer
+}
+
+// SetClientCAPath registers the CA used to verify client certificates when
+// serving over mTLS. It must be called, if at all, before Run.
+func (p *producer) SetClientCAPath(path string) {
+	p.clientCAPath = path
+}
+
+// New returns a Prometheus producer, applying Config defaults.
+func New(cfg Config) (producers.MetricsProducer, error) {
+	if cfg.Path == "" {
+		cfg.Path = "/metrics"
+	}
+	if cfg.Port == 0 {
+		return nil, fmt.Errorf("prometheus producer requires a port")
+	}
+
+	p := &producer{
+		config:  cfg,
+		descs:   map[string]*prometheus.Desc{},
+		samples: map[string]map[string]sample{},
+	}
+
+	p.registry = prometheus.NewRegistry()
+	p.registry.MustRegister(p)
+	return p, nil
+}
+
+// Run consumes MetricsMessages off in and updates the producer's samples
+// until the context is cancelled. It blocks on ListenAndServe for the
+// lifetime of the producer.
+func (p *producer) Run(ctx context.Context, in chan producers.MetricsMessage) error {
+	go func() {
+		for {
+			select {
+			case msg, ok := <-in:
+				if !ok {
+					return
+				}
+				p.observe(msg)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle(p.config.Path, p.handler())
+
+	p.server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", p.config.Port),
+		Handler: mux,
+	}
+
+	// Close the listener when the context is cancelled, so a restart can
+	// rebind the port.
+	go func() {
+		<-ctx.Done()
+		p.server.Close()
+	}()
+
+	log.Infof("Starting Prometheus producer on port %d, path %s", p.config.Port, p.config.Path)
+
+	if p.config.TLS != nil {
+		if p.clientCAPath != "" {
+			tlsConfig, err := httpProducer.ListenerTLSConfig(p.clientCAPath)
+			if err != nil {
+				return err
+			}
+			p.server.TLSConfig = tlsConfig
+		}
+		return p.server.ListenAndServeTLS(p.config.TLS.CertificatePath, p.config.TLS.KeyPath)
+	}
+	return p.server.ListenAndServe()
+}
+
+// handler wraps promhttp's handler with optional basic auth and, when an
+// authorizer is set, delegated bouncer authorization for callers outside
+// loopback.
+func (p *producer) handler() http.Handler {
+	var promHandler http.Handler = promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+
+	if p.config.BasicAuth != nil {
+		inner := promHandler
+		promHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != p.config.BasicAuth.Username || pass != p.config.BasicAuth.Password {
+				w.Header().Set("WWW-Authenticate", `Basic realm="dcos-metrics"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			inner.ServeHTTP(w, r)
+		})
+	}
+
+	return httpProducer.LoopbackExempt(p.authorizer, promHandler)
+}
+
+// Describe implements prometheus.Collector. Descs are created lazily as new
+// metric names are observed, so nothing is sent here; the registry treats
+// this collector as unchecked.
+func (p *producer) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector, emitting the latest sample of
+// every series observed since startup.
+func (p *producer) Collect(ch chan<- prometheus.Metric) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, series := range p.samples {
+		desc := p.descs[name]
+		for _, s := range series {
+			m, err := prometheus.NewConstMetric(desc, s.valueType, s.value, s.labels...)
+			if err != nil {
+				log.Warnf("could not create metric %s: %s", name, err)
+				continue
+			}
+			ch <- m
+		}
+	}
+}
+
+func labelValues(msg producers.MetricsMessage) []string {
+	return []string{
+		msg.Dimensions.MesosID,
+		msg.Dimensions.ClusterID,
+		msg.Dimensions.FrameworkName,
+		msg.Dimensions.ExecutorID,
+		msg.Dimensions.ContainerID,
+	}
+}
+
+// observe records the most recent value of each datapoint in msg, keyed by
+// metric name and the full label tuple so that series differing in any
+// dimension - not just container_id - don't collide.
+func (p *producer) observe(msg producers.MetricsMessage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	labels := labelValues(msg)
+	seriesKey := strings.Join(labels, "/")
+
+	for _, dp := range msg.Datapoints {
+		value, ok := toFloat64(dp.Value)
+		if !ok {
+			log.Warnf("skipping non-numeric datapoint %s", dp.Name)
+			continue
+		}
+
+		valueType := prometheus.GaugeValue
+		if counterMetrics[dp.Name] {
+			valueType = prometheus.CounterValue
+		}
+
+		if _, ok := p.descs[dp.Name]; !ok {
+			p.descs[dp.Name] = prometheus.NewDesc(
+				"dcos_"+dp.Name,
+				fmt.Sprintf("DC/OS metric %s", dp.Name),
+				labelNames, nil,
+			)
+			p.samples[dp.Name] = map[string]sample{}
+		}
+
+		p.samples[dp.Name][seriesKey] = sample{
+			valueType: valueType,
+			value:     value,
+			labels:    labels,
+		}
+	}
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,38 @@
+package statsd
+
+// Format selects the wire format used when writing metrics.
+type Format string
+
+// Supported Format values.
+const (
+	// FormatStatsd emits plain StatsD lines: "name:value|type".
+	FormatStatsd Format = "statsd"
+	// FormatDogStatsd emits DogStatsD-extended lines with a tag suffix:
+	// "name:value|type|#k:v,k:v".
+	FormatDogStatsd Format = "dogstatsd"
+)
+
+// defaultMTU is the default packet size to fill before flushing, chosen to
+// stay under the common 1500-byte Ethernet MTU once UDP/IP headers are
+// accounted for.
+const defaultMTU = 1432
+
+// Config contains configuration options for the StatsD/DogStatsD producer.
+type Config struct {
+	// Host/Port are used unless SocketPath is set, in which case metrics
+	// are written to a Unix datagram socket instead of UDP.
+	Host       string `yaml:"host"`
+	Port       int    `yaml:"port"`
+	SocketPath string `yaml:"socket_path,omitempty"`
+
+	Format      Format `yaml:"format"`
+	FlushPeriod int    `yaml:"flush_period"` // seconds
+	MTU         int    `yaml:"mtu,omitempty"`
+
+	// SampleRate applies to all datapoints unless overridden per metric
+	// name in MetricSampleRates. A SampleRate of 0 (or unset) means 1. A
+	// rate of 0 in MetricSampleRates suppresses that metric entirely,
+	// which is useful for silencing high-cardinality series.
+	SampleRate        float64            `yaml:"sample_rate"`
+	MetricSampleRates map[string]float64 `yaml:"metric_sample_rates,omitempty"`
+}
@@ -0,0 +1,183 @@
+// Package statsd implements a producers.MetricsProducer that pushes
+// DC/OS metrics to a local StatsD or DogStatsD agent over UDP or a Unix
+// datagram socket, batching datapoints into MTU-sized packets.
+package statsd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/dcos/dcos-metrics/producers"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// producer implements producers.MetricsProducer for StatsD/DogStatsD.
+type producer struct {
+	config Config
+	conn   net.Conn
+	mtu    int
+
+	buf *bytes.Buffer
+}
+
+// New constructs a StatsD producer from cfg. The socket is not opened until
+// Run is called.
+func New(cfg Config) (producers.MetricsProducer, error) {
+	if cfg.SocketPath == "" && (cfg.Host == "" || cfg.Port == 0) {
+		return nil, fmt.Errorf("statsd producer requires either socket_path or host+port")
+	}
+	if cfg.Format == "" {
+		cfg.Format = FormatStatsd
+	}
+	if cfg.SampleRate == 0 {
+		cfg.SampleRate = 1
+	}
+	mtu := cfg.MTU
+	if mtu == 0 {
+		mtu = defaultMTU
+	}
+
+	return &producer{
+		config: cfg,
+		mtu:    mtu,
+		buf:    &bytes.Buffer{},
+	}, nil
+}
+
+// Run opens the configured socket and writes MetricsMessages off in until
+// the context is cancelled, flushing on FlushPeriod or whenever the
+// buffered packet would exceed the configured MTU.
+func (p *producer) Run(ctx context.Context, in chan producers.MetricsMessage) error {
+	conn, err := p.dial()
+	if err != nil {
+		return err
+	}
+	p.conn = conn
+	defer conn.Close()
+
+	flushPeriod := time.Duration(p.config.FlushPeriod) * time.Second
+	if flushPeriod <= 0 {
+		flushPeriod = time.Second
+	}
+	ticker := time.NewTicker(flushPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-in:
+			if !ok {
+				p.flush()
+				return nil
+			}
+			p.write(msg)
+		case <-ticker.C:
+			p.flush()
+		case <-ctx.Done():
+			p.flush()
+			return nil
+		}
+	}
+}
+
+func (p *producer) dial() (net.Conn, error) {
+	if p.config.SocketPath != "" {
+		return net.Dial("unixgram", p.config.SocketPath)
+	}
+	return net.Dial("udp", net.JoinHostPort(p.config.Host, strconv.Itoa(p.config.Port)))
+}
+
+// write renders every datapoint in msg to a StatsD line, appending it to
+// the current packet buffer and flushing whenever adding the next line
+// would exceed the MTU.
+func (p *producer) write(msg producers.MetricsMessage) {
+	for _, dp := range msg.Datapoints {
+		rate := p.sampleRateFor(dp.Name)
+		if rate <= 0 || !shouldSample(rate) {
+			continue
+		}
+
+		line := p.formatLine(msg, dp, rate)
+		if p.buf.Len() > 0 && p.buf.Len()+len(line)+1 > p.mtu {
+			p.flush()
+		}
+		if p.buf.Len() > 0 {
+			p.buf.WriteByte('\n')
+		}
+		p.buf.WriteString(line)
+	}
+}
+
+func (p *producer) sampleRateFor(name string) float64 {
+	if rate, ok := p.config.MetricSampleRates[name]; ok {
+		return rate
+	}
+	return p.config.SampleRate
+}
+
+func shouldSample(rate float64) bool {
+	return rate >= 1 || rand.Float64() < rate
+}
+
+// formatLine renders a single datapoint as "name:value|g[|@rate][|#tags]".
+// Every DC/OS datapoint is reported as a gauge: several (e.g.
+// cpus_user_time_secs) are cumulative counters from Mesos' perspective, but
+// StatsD counters are deltas the agent adds to a running total on every
+// flush, which would double-count an already-cumulative value.
+func (p *producer) formatLine(msg producers.MetricsMessage, dp producers.Datapoint, rate float64) string {
+	line := fmt.Sprintf("%s:%v|g", dp.Name, dp.Value)
+	if rate < 1 {
+		line += fmt.Sprintf("|@%g", rate)
+	}
+	if p.config.Format == FormatDogStatsd {
+		if tags := dogStatsdTags(msg); tags != "" {
+			line += "|#" + tags
+		}
+	}
+	return line
+}
+
+// dogStatsdTags renders a MetricsMessage's dimensions as a DogStatsD tag
+// suffix: "mesos_id:...,framework_name:...,executor_id:...,container_id:...,cluster_id:...".
+func dogStatsdTags(msg producers.MetricsMessage) string {
+	tags := []struct {
+		key   string
+		value string
+	}{
+		{"mesos_id", msg.Dimensions.MesosID},
+		{"framework_name", msg.Dimensions.FrameworkName},
+		{"executor_id", msg.Dimensions.ExecutorID},
+		{"container_id", msg.Dimensions.ContainerID},
+		{"cluster_id", msg.Dimensions.ClusterID},
+	}
+
+	var buf bytes.Buffer
+	for _, tag := range tags {
+		if tag.value == "" {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(tag.key)
+		buf.WriteByte(':')
+		buf.WriteString(tag.value)
+	}
+	return buf.String()
+}
+
+// flush writes the current packet buffer to the socket and resets it.
+func (p *producer) flush() {
+	if p.buf.Len() == 0 {
+		return
+	}
+	if _, err := p.conn.Write(p.buf.Bytes()); err != nil {
+		log.Errorf("statsd producer: could not write packet: %s", err)
+	}
+	p.buf.Reset()
+}
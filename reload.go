@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/dcos/dcos-metrics/collector/mesos_agent"
+	"github.com/dcos/dcos-metrics/collector/node"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// configManager holds the currently active Config behind an atomic.Value so
+// collectors and producers can read a consistent snapshot at any time while
+// a SIGHUP-triggered reload is in flight on another goroutine.
+type configManager struct {
+	value atomic.Value
+	args  []string
+}
+
+// newConfigManager stores initial as the active configuration. args is
+// retained so that a later Reload() re-parses the same CLI flags on top of
+// a freshly-read config file.
+func newConfigManager(initial Config, args []string) *configManager {
+	m := &configManager{args: args}
+	m.value.Store(initial)
+	return m
+}
+
+// Get returns the currently active configuration.
+func (m *configManager) Get() Config {
+	return m.value.Load().(Config)
+}
+
+// RedactedJSON implements httpProducer.ConfigProvider, so the HTTP
+// producer's /config endpoint always reflects the latest reload.
+func (m *configManager) RedactedJSON() ([]byte, error) {
+	return m.Get().RedactedJSON()
+}
+
+// reloadDiff names the subsystems whose configuration changed between two
+// reloads, so the caller can restart only those subsystems.
+type reloadDiff struct {
+	nodeCollector       bool
+	mesosAgentCollector bool
+	httpProducer        bool
+	prometheusProducer  bool
+	kafkaProducer       bool
+	statsdProducer      bool
+}
+
+// any reports whether at least one subsystem changed.
+func (d reloadDiff) any() bool {
+	return d.nodeCollector || d.mesosAgentCollector || d.httpProducer ||
+		d.prometheusProducer || d.kafkaProducer || d.statsdProducer
+}
+
+// Reload re-runs getNewConfig with the manager's original args (so CLI
+// flags still take precedence over the file), swaps it in atomically, and
+// returns which subsystems changed relative to the previous configuration.
+func (m *configManager) Reload() (reloadDiff, error) {
+	old := m.Get()
+
+	updated, err := getNewConfig(m.args)
+	if err != nil {
+		return reloadDiff{}, err
+	}
+
+	m.value.Store(updated)
+
+	return reloadDiff{
+		nodeCollector:       nodeCollectorChanged(old.Collector.Node, updated.Collector.Node),
+		mesosAgentCollector: mesosAgentCollectorChanged(old.Collector.MesosAgent, updated.Collector.MesosAgent),
+		httpProducer:        !reflect.DeepEqual(old.Producers.HTTPProducerConfig, updated.Producers.HTTPProducerConfig),
+		prometheusProducer:  !reflect.DeepEqual(old.Producers.PrometheusProducerConfig, updated.Producers.PrometheusProducerConfig),
+		kafkaProducer:       !reflect.DeepEqual(old.Producers.KafkaProducerConfig, updated.Producers.KafkaProducerConfig),
+		statsdProducer:      !reflect.DeepEqual(old.Producers.StatsdProducerConfig, updated.Producers.StatsdProducerConfig),
+	}, nil
+}
+
+// nodeCollectorChanged compares only the parsed config fields of a
+// node.NodeCollector, not its NodeInfo (populated at runtime by
+// getNodeInfo on every getNewConfig call, regardless of operator intent).
+func nodeCollectorChanged(old, updated *node.NodeCollector) bool {
+	if old == nil || updated == nil {
+		return old != updated
+	}
+	return old.PollPeriod != updated.PollPeriod
+}
+
+// mesosAgentCollectorChanged compares only the parsed config fields of a
+// mesos_agent.MesosAgentCollector, not its NodeInfo or HTTPClient - both
+// are populated fresh by getNewConfig on every reload and are not a
+// reliable signal of operator intent to restart the collector.
+func mesosAgentCollectorChanged(old, updated *mesos_agent.MesosAgentCollector) bool {
+	if old == nil || updated == nil {
+		return old != updated
+	}
+	return old.PollPeriod != updated.PollPeriod || old.Port != updated.Port
+}
+
+// watchSIGHUP invokes reload every time the process receives SIGHUP, e.g.
+// `kill -HUP <pid>`, and logs the result. It runs until the process exits.
+func watchSIGHUP(reload func() (reloadDiff, error)) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	go func() {
+		for range sigCh {
+			log.Info("Received SIGHUP, reloading configuration")
+
+			diff, err := reload()
+			if err != nil {
+				log.Errorf("Config reload failed, keeping previous configuration: %s", err)
+				continue
+			}
+			if !diff.any() {
+				log.Info("Config reload complete, no subsystems require a restart")
+				continue
+			}
+			log.Infof("Config reload complete: %+v", diff)
+		}
+	}()
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	httpHelpers "github.com/dcos/dcos-metrics/http_helpers"
+	"github.com/dcos/dcos-metrics/producers"
+	httpProducer "github.com/dcos/dcos-metrics/producers/http"
+	kafkaProducer "github.com/dcos/dcos-metrics/producers/kafka"
+	prometheusProducer "github.com/dcos/dcos-metrics/producers/prometheus"
+	statsdProducer "github.com/dcos/dcos-metrics/producers/statsd"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// authorizable is implemented by producers that expose an optional
+// delegated-authorization endpoint (currently the Prometheus and HTTP
+// producers' handlers).
+type authorizable interface {
+	SetAuthorizer(*httpHelpers.BouncerAuthorizer)
+}
+
+// mtlsConfigurable is implemented by producers that terminate their own TLS
+// listener and need the CA used to verify client certificates.
+type mtlsConfigurable interface {
+	SetClientCAPath(string)
+}
+
+// supervisor starts, restarts, and stops the goroutines backing every
+// configurable subsystem, so a SIGHUP-triggered config reload can tear down
+// and relaunch only the ones whose configuration actually changed. It
+// covers both the producers.MetricsProducer implementations (http/
+// prometheus/kafka/statsd) and the Node/MesosAgent collectors, which
+// publish into the same broadcaster those producers read from.
+type supervisor struct {
+	manager           *configManager
+	metrics           func(name string) chan producers.MetricsMessage
+	publish           func(producers.MetricsMessage)
+	authorizer        *httpHelpers.BouncerAuthorizer
+	caCertificatePath string
+
+	mu    sync.Mutex
+	procs map[string]context.CancelFunc
+}
+
+func newSupervisor(manager *configManager, metricsChanFor func(name string) chan producers.MetricsMessage, publish func(producers.MetricsMessage)) *supervisor {
+	return &supervisor{
+		manager: manager,
+		metrics: metricsChanFor,
+		publish: publish,
+		procs:   map[string]context.CancelFunc{},
+	}
+}
+
+// SetAuthorizer registers the delegated authorizer passed to any
+// authorizable producer this supervisor (re)starts from now on.
+func (s *supervisor) SetAuthorizer(authorizer *httpHelpers.BouncerAuthorizer) {
+	s.authorizer = authorizer
+}
+
+// SetCACertificatePath registers the CA certificate passed to any
+// mtlsConfigurable producer this supervisor (re)starts from now on.
+func (s *supervisor) SetCACertificatePath(path string) {
+	s.caCertificatePath = path
+}
+
+// reconcile starts/restarts/stops producers named in diff to match the
+// manager's current configuration.
+func (s *supervisor) reconcile(diff reloadDiff) {
+	cfg := s.manager.Get()
+
+	if diff.nodeCollector {
+		s.restartCollector("collector.node", func(ctx context.Context) error {
+			return cfg.Collector.Node.Run(ctx, s.publish)
+		})
+	}
+
+	if diff.mesosAgentCollector {
+		s.restartCollector("collector.mesos_agent", func(ctx context.Context) error {
+			return cfg.Collector.MesosAgent.Run(ctx, s.publish)
+		})
+	}
+
+	if diff.httpProducer {
+		s.restart("producer.http", func() (producers.MetricsProducer, error) {
+			return httpProducer.New(cfg.Producers.HTTPProducerConfig)
+		})
+	}
+
+	if diff.prometheusProducer {
+		if cfg.Producers.PrometheusProducerConfig.Port == 0 {
+			s.stop("producer.prometheus")
+		} else {
+			s.restart("producer.prometheus", func() (producers.MetricsProducer, error) {
+				return prometheusProducer.New(cfg.Producers.PrometheusProducerConfig)
+			})
+		}
+	}
+
+	if diff.kafkaProducer {
+		if len(cfg.Producers.KafkaProducerConfig.Brokers) == 0 {
+			s.stop("producer.kafka")
+		} else {
+			s.restart("producer.kafka", func() (producers.MetricsProducer, error) {
+				return kafkaProducer.New(cfg.Producers.KafkaProducerConfig)
+			})
+		}
+	}
+
+	if diff.statsdProducer {
+		enabled := cfg.Producers.StatsdProducerConfig.Host != "" || cfg.Producers.StatsdProducerConfig.SocketPath != ""
+		if !enabled {
+			s.stop("producer.statsd")
+		} else {
+			s.restart("producer.statsd", func() (producers.MetricsProducer, error) {
+				return statsdProducer.New(cfg.Producers.StatsdProducerConfig)
+			})
+		}
+	}
+}
+
+// restart stops the previous instance of name, if any, constructs a new
+// one via newProducer, and runs it on a fresh context.
+func (s *supervisor) restart(name string, newProducer func() (producers.MetricsProducer, error)) {
+	s.stop(name)
+
+	producer, err := newProducer()
+	if err != nil {
+		log.Errorf("Could not restart %s: %s", name, err)
+		return
+	}
+
+	if a, ok := producer.(authorizable); ok {
+		a.SetAuthorizer(s.authorizer)
+	}
+	if m, ok := producer.(mtlsConfigurable); ok {
+		m.SetClientCAPath(s.caCertificatePath)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	s.procs[name] = cancel
+	s.mu.Unlock()
+
+	go func() {
+		if err := producer.Run(ctx, s.metrics(name)); err != nil && ctx.Err() == nil {
+			log.Errorf("%s exited: %s", name, err)
+		}
+	}()
+
+	log.Infof("Restarted %s", name)
+}
+
+// restartCollector stops the previous instance of name, if any, and runs
+// start on a fresh context. Unlike restart, collectors publish directly
+// into the broadcaster (via s.publish) rather than consuming from a
+// per-producer channel.
+func (s *supervisor) restartCollector(name string, start func(context.Context) error) {
+	s.stop(name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	s.procs[name] = cancel
+	s.mu.Unlock()
+
+	go func() {
+		if err := start(ctx); err != nil && ctx.Err() == nil {
+			log.Errorf("%s exited: %s", name, err)
+		}
+	}()
+
+	log.Infof("Restarted %s", name)
+}
+
+// stop cancels the running instance of name, if any.
+func (s *supervisor) stop(name string) {
+	s.mu.Lock()
+	cancel, ok := s.procs[name]
+	delete(s.procs, name)
+	s.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}